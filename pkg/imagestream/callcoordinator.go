@@ -0,0 +1,120 @@
+package imagestream
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"golang.org/x/sync/singleflight"
+
+	rerrors "github.com/openshift/image-registry/pkg/errors"
+)
+
+// callCoordinator deduplicates concurrent fetches for the same (namespace,
+// name, kind) key and makes them cancellation-aware: a caller that gives up
+// on ctx stops waiting immediately, while the underlying fetch itself is
+// only canceled once every caller waiting on that key has given up, so one
+// client disconnecting mid-pull doesn't abort the request for siblings
+// still waiting on it. The shared fn still runs under the first joiner's
+// ctx (minus its cancellation and deadline), so request-scoped values like a logger or
+// trace id carry over into the coalesced call instead of being dropped in
+// favor of a bare background context.
+type callCoordinator struct {
+	group singleflight.Group
+
+	mu        sync.Mutex
+	inflight  map[string]*coordinatedCall
+	nextEpoch int64
+}
+
+type coordinatedCall struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters int
+	// sfKey is the singleflight.Group key for this call's epoch. It is
+	// derived from the coordinator key plus a monotonically increasing
+	// epoch number so that once the bookkeeping entry for a key is torn
+	// down (every prior waiter gave up), the next join starts a brand new
+	// singleflight.Group.DoChan call instead of attaching to whatever is
+	// left of the previous, possibly already-canceled, call still settling
+	// under the plain key.
+	sfKey string
+}
+
+func newCallCoordinator() *callCoordinator {
+	return &callCoordinator{inflight: make(map[string]*coordinatedCall)}
+}
+
+// join adds a waiter to the in-flight call for key, starting one with
+// callerCtx as its parent if none exists yet. callerCtx is only used for the
+// values it carries over into the shared call's context -- the shared call
+// must keep running for any other waiter even after the caller that happened
+// to start it cancels, so its cancellation and deadline are both stripped.
+func (c *callCoordinator) join(callerCtx context.Context, key string) *coordinatedCall {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	call, ok := c.inflight[key]
+	if !ok {
+		c.nextEpoch++
+		ctx, cancel := context.WithCancel(context.WithoutCancel(callerCtx))
+		call = &coordinatedCall{
+			ctx:    ctx,
+			cancel: cancel,
+			sfKey:  fmt.Sprintf("%s#%d", key, c.nextEpoch),
+		}
+		c.inflight[key] = call
+	}
+	call.waiters++
+	return call
+}
+
+func (c *callCoordinator) leave(key string, call *coordinatedCall) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	call.waiters--
+	if call.waiters <= 0 {
+		call.cancel()
+		delete(c.inflight, key)
+	}
+}
+
+// do runs fn, coalescing concurrent calls for the same key into a single
+// invocation shared across all callers currently waiting on that key. If
+// ctx is canceled before fn completes, do returns an error wrapping
+// ctx.Err() to this caller without waiting for the others; the shared fn
+// keeps running for them.
+func (c *callCoordinator) do(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, rerrors.Error)) (interface{}, rerrors.Error) {
+	call := c.join(ctx, key)
+	defer c.leave(key, call)
+
+	resultCh := c.group.DoChan(call.sfKey, func() (interface{}, error) {
+		v, rErr := fn(call.ctx)
+		return v, asError(rErr)
+	})
+
+	select {
+	case res := <-resultCh:
+		return res.Val, asRError(res.Err)
+	case <-ctx.Done():
+		return nil, rerrors.NewError(ErrImageStreamUnknownErrorCode, "call canceled by caller", ctx.Err())
+	}
+}
+
+func asError(rErr rerrors.Error) error {
+	if rErr == nil {
+		return nil
+	}
+	return rErr
+}
+
+func asRError(err error) rerrors.Error {
+	if err == nil {
+		return nil
+	}
+	if rErr, ok := err.(rerrors.Error); ok {
+		return rErr
+	}
+	return rerrors.NewError(ErrImageStreamUnknownErrorCode, err.Error(), err)
+}
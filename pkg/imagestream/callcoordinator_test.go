@@ -0,0 +1,163 @@
+package imagestream
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rerrors "github.com/openshift/image-registry/pkg/errors"
+)
+
+func TestCallCoordinatorDeduplicatesConcurrentCallers(t *testing.T) {
+	c := newCallCoordinator()
+
+	var calls int32
+	release := make(chan struct{})
+
+	fn := func(ctx context.Context) (interface{}, rerrors.Error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "value", nil
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	results := make([]interface{}, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, _ := c.do(context.Background(), "key", fn)
+			results[i] = v
+		}(i)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("fn called %d times, want 1", got)
+	}
+	for i, v := range results {
+		if v != "value" {
+			t.Fatalf("result[%d] = %v, want %q", i, v, "value")
+		}
+	}
+}
+
+func TestCallCoordinatorCallerCancellationDoesNotAbortSiblings(t *testing.T) {
+	c := newCallCoordinator()
+
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (interface{}, rerrors.Error) {
+		<-release
+		return "value", nil
+	}
+
+	cancelCtx, cancel := context.WithCancel(context.Background())
+
+	canceledDone := make(chan struct{})
+	go func() {
+		if _, rErr := c.do(cancelCtx, "key", fn); rErr == nil {
+			t.Error("expected canceled caller to get an error")
+		}
+		close(canceledDone)
+	}()
+
+	siblingResult := make(chan interface{}, 1)
+	go func() {
+		v, _ := c.do(context.Background(), "key", fn)
+		siblingResult <- v
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	<-canceledDone
+
+	close(release)
+	if v := <-siblingResult; v != "value" {
+		t.Fatalf("sibling got %v, want %q", v, "value")
+	}
+}
+
+type ctxKey string
+
+func TestCallCoordinatorSharedCallSeesFirstJoinersValues(t *testing.T) {
+	c := newCallCoordinator()
+
+	release := make(chan struct{})
+	var sawValue interface{}
+	fn := func(ctx context.Context) (interface{}, rerrors.Error) {
+		sawValue = ctx.Value(ctxKey("trace-id"))
+		<-release
+		return "value", nil
+	}
+
+	firstCtx := context.WithValue(context.Background(), ctxKey("trace-id"), "abc123")
+
+	firstDone := make(chan struct{})
+	go func() {
+		_, _ = c.do(firstCtx, "key", fn)
+		close(firstDone)
+	}()
+
+	// Let the first caller join before the second arrives, so the second is
+	// guaranteed to coalesce onto the call the first started.
+	time.Sleep(20 * time.Millisecond)
+
+	secondDone := make(chan struct{})
+	go func() {
+		_, _ = c.do(context.Background(), "key", fn)
+		close(secondDone)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	<-firstDone
+	<-secondDone
+
+	if sawValue != "abc123" {
+		t.Fatalf("shared call saw trace id %v, want %q", sawValue, "abc123")
+	}
+}
+
+func TestCallCoordinatorStartsFreshCallAfterAllWaitersLeave(t *testing.T) {
+	c := newCallCoordinator()
+
+	firstCtx, firstCancel := context.WithCancel(context.Background())
+	firstStarted := make(chan struct{})
+	unblockFirst := make(chan struct{})
+
+	firstDone := make(chan struct{})
+	go func() {
+		_, _ = c.do(firstCtx, "key", func(ctx context.Context) (interface{}, rerrors.Error) {
+			close(firstStarted)
+			<-unblockFirst
+			<-ctx.Done()
+			return nil, rerrors.NewError(ErrImageStreamUnknownErrorCode, "aborted", ctx.Err())
+		})
+		close(firstDone)
+	}()
+
+	<-firstStarted
+	firstCancel()
+	<-firstDone
+	close(unblockFirst)
+
+	// Give the coordinator a moment to tear down the bookkeeping entry for
+	// "key" now that its only waiter has left.
+	time.Sleep(20 * time.Millisecond)
+
+	v, rErr := c.do(context.Background(), "key", func(ctx context.Context) (interface{}, rerrors.Error) {
+		return "fresh", nil
+	})
+	if rErr != nil {
+		t.Fatalf("new caller after all waiters left got error: %v", rErr)
+	}
+	if v != "fresh" {
+		t.Fatalf("new caller got %v, want %q", v, "fresh")
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/docker/distribution"
 	dcontext "github.com/docker/distribution/context"
 	"github.com/opencontainers/go-digest"
 
@@ -31,11 +34,21 @@ const (
 	ErrImageStreamForbiddenCode     = ErrImageStreamCode + "Forbidden"
 )
 
-// ProjectObjectListStore represents a cache of objects indexed by a project name.
-// Used to store a list of items per namespace.
+// ProjectObjectListStore represents a cache of objects indexed by a project
+// name (or, for stores keyed by a single image stream rather than a whole
+// project, its Reference()). The default implementation, returned by
+// NewObjectListStore, supports a per-entry ttl, negative caching and an
+// eviction callback.
 type ProjectObjectListStore interface {
-	Add(namespace string, obj runtime.Object) error
+	// Add caches obj under namespace for ttl. Callers that want to cache a
+	// negative result (e.g. an empty list) should pass a shorter ttl than
+	// they would for a populated one.
+	Add(namespace string, obj runtime.Object, ttl time.Duration) error
 	Get(namespace string) (obj runtime.Object, exists bool, err error)
+	// Invalidate removes any cached entry for namespace immediately, without
+	// waiting for its ttl to elapse. Used, for example, when a 401/403
+	// observed downstream means cached secrets can no longer be trusted.
+	Invalidate(namespace string)
 }
 
 type ImageStream interface {
@@ -43,18 +56,54 @@ type ImageStream interface {
 	Clone(namespace, name string) ImageStream
 	Exists(ctx context.Context) (bool, rerrors.Error)
 
-	GetImageOfImageStream(ctx context.Context, dgst digest.Digest) (*imageapiv1.Image, rerrors.Error)
+	// GetImageOfImageStream retrieves the image for dgst. tag is the name the
+	// client pulled by, or "" if it pulled by digest; when tag is non-empty
+	// and only has a Spec.Tags DockerImage reference so far, it is imported
+	// synchronously first, the same way TagIsInsecure accepts an optional
+	// tag alongside dgst.
+	GetImageOfImageStream(ctx context.Context, tag string, dgst digest.Digest) (*imageapiv1.Image, rerrors.Error)
 	CreateImageStreamMapping(ctx context.Context, userClient client.Interface, tag string, image *imageapiv1.Image) rerrors.Error
 	ResolveImageID(ctx context.Context, dgst digest.Digest) (*imageapiv1.TagEvent, rerrors.Error)
 
+	// ResolveTag resolves tag to the digest it currently points at. If the
+	// tag has a Spec.Tags DockerImage reference but has never been imported,
+	// it is imported synchronously first. It is the opt-in counterpart to
+	// Tags: callers that are happy with whatever has already been imported
+	// should keep using Tags. GetImageOfImageStream runs the same
+	// import-if-needed check internally when called with a tag.
+	ResolveTag(ctx context.Context, tag string) (digest.Digest, rerrors.Error)
+
 	HasBlob(ctx context.Context, dgst digest.Digest) (bool, *imageapiv1.ImageStreamLayers, *imageapiv1.Image)
 	RemoteRepositoriesForBlob(ctx context.Context, dgst digest.Digest) ([]RemoteRepository, []ImageStreamReference, rerrors.Error)
 	RemoteRepositoriesForManifest(ctx context.Context, dgst digest.Digest) ([]RemoteRepository, []ImageStreamReference, rerrors.Error)
 	GetLimitRangeList(ctx context.Context, cache ProjectObjectListStore) (*corev1.LimitRangeList, rerrors.Error)
-	GetSecrets() ([]corev1.Secret, rerrors.Error)
+	// GetSecrets returns the pull secrets linked to this image stream. When
+	// cache is non-nil, results are cached keyed by Reference() so sibling
+	// repositories in the same image stream share one lookup; callers that
+	// observe a 401/403 while using these secrets should call
+	// cache.Invalidate(is.Reference()) so the next call re-fetches them.
+	GetSecrets(ctx context.Context, cache ProjectObjectListStore) ([]corev1.Secret, rerrors.Error)
 
 	TagIsInsecure(ctx context.Context, tag string, dgst digest.Digest) (bool, rerrors.Error)
 	Tags(ctx context.Context) (map[string]digest.Digest, rerrors.Error)
+
+	// TagHistory returns every tag event recorded for each tag, sorted from
+	// newest to oldest, unlike Tags which only returns each tag's current head.
+	TagHistory(ctx context.Context) (map[string][]imageapiv1.TagEvent, rerrors.Error)
+
+	// SetBlobStatter wires in a distribution.BlobStatter that
+	// CreateImageStreamMapping uses to backfill zero-sized layers before
+	// submitting a mapping to the master. Repository middleware should call
+	// this once, right after New/Clone, passing the BlobStatter for the
+	// storage backing this repository.
+	SetBlobStatter(statter distribution.BlobStatter)
+
+	// SetSharedCache wires in a ProjectObjectListStore, keyed by Reference(),
+	// that getStream's callers share across sibling ImageStream instances
+	// constructed from the same repository middleware for the same image
+	// stream -- so concurrent pulls of different tags don't each pay for
+	// their own master API round trip.
+	SetSharedCache(cache ProjectObjectListStore)
 }
 
 type imageStream struct {
@@ -67,6 +116,22 @@ type imageStream struct {
 
 	// imageStreamGetter fetches and caches an image stream. The image stream stays cached for the entire time of handling single repository-scoped request.
 	imageStreamGetter *cachedImageStreamGetter
+
+	// importDebounce coalesces concurrent lazy tag imports triggered by ResolveTag.
+	importDebounce *tagImportDebouncer
+
+	// blobStatter, when set via SetBlobStatter, is used by
+	// CreateImageStreamMapping to backfill zero-sized layers.
+	blobStatter distribution.BlobStatter
+
+	// sharedCache, when set via SetSharedCache, lets getStream share a
+	// fetched image stream with sibling ImageStream instances.
+	sharedCache ProjectObjectListStore
+
+	// calls coalesces concurrent fetches of the image stream, its layers and
+	// its images so that a burst of pulls hitting an uncached repository
+	// only hits the master API once per distinct object.
+	calls *callCoordinator
 }
 
 var _ ImageStream = &imageStream{}
@@ -82,6 +147,8 @@ func New(namespace, name string, client client.Interface) ImageStream {
 			name:         name,
 			isNamespacer: client,
 		},
+		importDebounce: newTagImportDebouncer(),
+		calls:          newCallCoordinator(),
 	}
 }
 
@@ -103,56 +170,184 @@ func (is *imageStream) Clone(namespace, name string) ImageStream {
 			name:         name,
 			isNamespacer: is.registryOSClient,
 		},
+		importDebounce: newTagImportDebouncer(),
+		blobStatter:    is.blobStatter,
+		sharedCache:    is.sharedCache,
+		calls:          newCallCoordinator(),
 	}
 }
 
-// getImage retrieves the Image with digest `dgst`. No authorization check is done.
+// getImage retrieves the Image with digest `dgst`. No authorization check is
+// done. Concurrent calls for the same digest are coalesced into a single
+// master API request.
 func (is *imageStream) getImage(ctx context.Context, dgst digest.Digest) (*imageapiv1.Image, rerrors.Error) {
-	image, err := is.imageClient.Get(ctx, dgst)
+	v, rErr := is.calls.do(ctx, "image:"+dgst.String(), func(ctx context.Context) (interface{}, rerrors.Error) {
+		image, err := is.imageClient.Get(ctx, dgst)
+
+		switch {
+		case kerrors.IsNotFound(err):
+			return nil, rerrors.NewError(
+				ErrImageStreamImageNotFoundCode,
+				fmt.Sprintf("getImage: unable to find image digest %s in %s", dgst.String(), is.name),
+				err,
+			)
+		case err != nil:
+			return nil, rerrors.NewError(
+				ErrImageStreamUnknownErrorCode,
+				fmt.Sprintf("getImage: unable to get image digest %s in %s", dgst.String(), is.name),
+				err,
+			)
+		}
 
-	switch {
-	case kerrors.IsNotFound(err):
-		return nil, rerrors.NewError(
-			ErrImageStreamImageNotFoundCode,
-			fmt.Sprintf("getImage: unable to find image digest %s in %s", dgst.String(), is.name),
-			err,
-		)
-	case err != nil:
-		return nil, rerrors.NewError(
-			ErrImageStreamUnknownErrorCode,
-			fmt.Sprintf("getImage: unable to get image digest %s in %s", dgst.String(), is.name),
-			err,
-		)
+		return image, nil
+	})
+	if rErr != nil {
+		return nil, rErr
 	}
-
-	return image, nil
+	if v == nil {
+		return nil, nil
+	}
+	return v.(*imageapiv1.Image), nil
 }
 
-// ResolveImageID returns latest TagEvent for specified imageID and an error if
-// there's more than one image matching the ID or when one does not exist.
-func (is *imageStream) ResolveImageID(ctx context.Context, dgst digest.Digest) (*imageapiv1.TagEvent, rerrors.Error) {
-	stream, rErr := is.imageStreamGetter.get()
+// sharedStreamCacheTTL bounds how long getStream trusts a stream it shared
+// via SetSharedCache with sibling ImageStream instances. It is kept short
+// since Status.Tags changes whenever a tag is pushed or imported.
+const sharedStreamCacheTTL = 2 * time.Second
+
+// getStream returns the cached image stream, coalescing concurrent fetches
+// for the same (namespace, name) into a single master API request, and
+// consulting the cross-repository shared cache first when one is set.
+func (is *imageStream) getStream(ctx context.Context) (*imageapiv1.ImageStream, rerrors.Error) {
+	if is.sharedCache != nil {
+		if obj, exists, _ := is.sharedCache.Get(is.Reference()); exists {
+			return obj.(*imageapiv1.ImageStream), nil
+		}
+	}
 
+	v, rErr := is.calls.do(ctx, "stream", func(ctx context.Context) (interface{}, rerrors.Error) {
+		return is.imageStreamGetter.get(ctx)
+	})
 	if rErr != nil {
-		return nil, convertImageStreamGetterError(rErr, fmt.Sprintf("ResolveImageID: failed to get image stream %s", is.Reference()))
+		return nil, rErr
+	}
+	if v == nil {
+		return nil, nil
 	}
+	stream := v.(*imageapiv1.ImageStream)
 
-	tagEvent, err := originutil.ResolveImageID(stream, dgst.String())
-	if err != nil {
-		code := ErrImageStreamUnknownErrorCode
+	if is.sharedCache != nil {
+		if err := is.sharedCache.Add(is.Reference(), stream, sharedStreamCacheTTL); err != nil {
+			dcontext.GetLogger(ctx).Errorf("getStream: failed to share cached image stream %s: %v", is.Reference(), err)
+		}
+	}
+
+	return stream, nil
+}
+
+// getLayers returns the cached image stream layers, coalescing concurrent
+// fetches for the same (namespace, name) into a single master API request.
+func (is *imageStream) getLayers(ctx context.Context) (*imageapiv1.ImageStreamLayers, rerrors.Error) {
+	v, rErr := is.calls.do(ctx, "layers", func(ctx context.Context) (interface{}, rerrors.Error) {
+		return is.imageStreamGetter.layers(ctx)
+	})
+	if rErr != nil {
+		return nil, rErr
+	}
+	if v == nil {
+		return nil, nil
+	}
+	return v.(*imageapiv1.ImageStreamLayers), nil
+}
+
+// ResolveImageID returns the most recent TagEvent for specified imageID. If
+// the same image digest was tagged more than once (for example a rollback
+// that re-tags an older image), the event with the highest Generation wins,
+// so a rolled-back or re-tagged image resolves to the DockerImageReference it
+// was most recently pulled under rather than whichever tag happened to be
+// listed first.
+func (is *imageStream) ResolveImageID(ctx context.Context, dgst digest.Digest) (*imageapiv1.TagEvent, rerrors.Error) {
+	history, rErr := is.TagHistory(ctx)
+	if rErr != nil {
+		return nil, rErr
+	}
 
-		if kerrors.IsNotFound(err) {
-			code = ErrImageStreamImageNotFoundCode
+	var best *imageapiv1.TagEvent
+	for _, events := range history {
+		for i := range events {
+			if events[i].Image != dgst.String() {
+				continue
+			}
+			if best == nil || newerTagEvent(&events[i], best) {
+				best = &events[i]
+			}
 		}
+	}
 
+	if best == nil {
 		return nil, rerrors.NewError(
-			code,
+			ErrImageStreamImageNotFoundCode,
 			fmt.Sprintf("ResolveImageID: unable to resolve ImageID %s in image stream %s", dgst.String(), is.Reference()),
-			err,
+			nil,
 		)
 	}
 
-	return tagEvent, nil
+	return best, nil
+}
+
+// TagHistory returns every tag event recorded for each tag in the image
+// stream, sorted from newest to oldest by Generation (ties broken by
+// Created). Unlike Tags, which only reports each tag's current head, this
+// exposes the full history so that digest lookups can prefer the freshest
+// event across tags.
+func (is *imageStream) TagHistory(ctx context.Context) (map[string][]imageapiv1.TagEvent, rerrors.Error) {
+	stream, err := is.getStream(ctx)
+	if err != nil {
+		return nil, convertImageStreamGetterError(err, fmt.Sprintf("TagHistory: failed to get image stream %s", is.Reference()))
+	}
+
+	history := make(map[string][]imageapiv1.TagEvent, len(stream.Status.Tags))
+
+	for _, t := range stream.Status.Tags {
+		items := make([]imageapiv1.TagEvent, len(t.Items))
+		copy(items, t.Items)
+
+		ptrs := make([]*imageapiv1.TagEvent, len(items))
+		for i := range items {
+			ptrs[i] = &items[i]
+		}
+		sort.Sort(ByGeneration(ptrs))
+
+		sorted := make([]imageapiv1.TagEvent, len(ptrs))
+		for i, e := range ptrs {
+			sorted[i] = *e
+		}
+
+		history[t.Tag] = sorted
+	}
+
+	return history, nil
+}
+
+// ByGeneration sorts tag events from newest to oldest by Generation, breaking
+// ties by Created so that two events imported in the same generation still
+// order consistently.
+type ByGeneration []*imageapiv1.TagEvent
+
+func (b ByGeneration) Len() int      { return len(b) }
+func (b ByGeneration) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b ByGeneration) Less(i, j int) bool {
+	return newerTagEvent(b[i], b[j])
+}
+
+// newerTagEvent reports whether a should be ordered before b: a higher
+// Generation wins, ties broken by Created so that two events imported in the
+// same generation still order consistently.
+func newerTagEvent(a, b *imageapiv1.TagEvent) bool {
+	if a.Generation != b.Generation {
+		return a.Generation > b.Generation
+	}
+	return b.Created.Before(&a.Created)
 }
 
 // GetStoredImageOfImageStream retrieves the Image with digest `dgst` and
@@ -188,7 +383,13 @@ func (is *imageStream) getStoredImageOfImageStream(ctx context.Context, dgst dig
 // NOTE: due to on the fly modification, the returned image object should
 // not be sent to the master API. If you need unmodified version of the
 // image object, please use getStoredImageOfImageStream.
-func (is *imageStream) GetImageOfImageStream(ctx context.Context, dgst digest.Digest) (*imageapiv1.Image, rerrors.Error) {
+func (is *imageStream) GetImageOfImageStream(ctx context.Context, tag string, dgst digest.Digest) (*imageapiv1.Image, rerrors.Error) {
+	if tag != "" {
+		if err := is.ensureTagImported(ctx, tag); err != nil {
+			return nil, err
+		}
+	}
+
 	image, tagEvent, err := is.getStoredImageOfImageStream(ctx, dgst)
 	if err != nil {
 		return nil, err
@@ -201,22 +402,57 @@ func (is *imageStream) GetImageOfImageStream(ctx context.Context, dgst digest.Di
 	return &img, nil
 }
 
-func (is *imageStream) GetSecrets() ([]corev1.Secret, rerrors.Error) {
-	secrets, err := is.registryOSClient.ImageStreamSecrets(is.namespace).Secrets(context.TODO(), is.name, metav1.GetOptions{})
-	if err != nil {
-		return nil, rerrors.NewError(
-			ErrImageStreamUnknownErrorCode,
-			fmt.Sprintf("GetSecrets: error getting secrets for repository %s", is.Reference()),
-			err,
-		)
+// secretsCacheTTL and secretsNegativeCacheTTL bound how long GetSecrets
+// trusts a cached result when a cache is supplied.
+const (
+	secretsCacheTTL         = 1 * time.Minute
+	secretsNegativeCacheTTL = 10 * time.Second
+)
+
+// GetSecrets returns the pull secrets linked to this image stream's
+// repository. A cache miss is coalesced through is.calls so that a burst of
+// concurrent callers racing to populate an empty cache only sends a single
+// ImageStreamSecrets request, not one per caller.
+func (is *imageStream) GetSecrets(ctx context.Context, cache ProjectObjectListStore) ([]corev1.Secret, rerrors.Error) {
+	if cache != nil {
+		if obj, exists, _ := cache.Get(is.Reference()); exists {
+			return obj.(*corev1.SecretList).Items, nil
+		}
 	}
-	return secrets.Items, nil
+
+	v, rErr := is.calls.do(ctx, "secrets", func(ctx context.Context) (interface{}, rerrors.Error) {
+		secrets, err := is.registryOSClient.ImageStreamSecrets(is.namespace).Secrets(ctx, is.name, metav1.GetOptions{})
+		if err != nil {
+			return nil, rerrors.NewError(
+				ErrImageStreamUnknownErrorCode,
+				fmt.Sprintf("GetSecrets: error getting secrets for repository %s", is.Reference()),
+				err,
+			)
+		}
+
+		if cache != nil {
+			ttl := secretsCacheTTL
+			if len(secrets.Items) == 0 {
+				ttl = secretsNegativeCacheTTL
+			}
+			if err := cache.Add(is.Reference(), secrets, ttl); err != nil {
+				dcontext.GetLogger(ctx).Errorf("GetSecrets: failed to cache secrets for %s: %v", is.Reference(), err)
+			}
+		}
+
+		return secrets, nil
+	})
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	return v.(*corev1.SecretList).Items, nil
 }
 
 // TagIsInsecure returns true if the given image stream or its tag allow for
 // insecure transport.
 func (is *imageStream) TagIsInsecure(ctx context.Context, tag string, dgst digest.Digest) (bool, rerrors.Error) {
-	stream, err := is.imageStreamGetter.get()
+	stream, err := is.getStream(ctx)
 
 	if err != nil {
 		return false, convertImageStreamGetterError(err, fmt.Sprintf("TagIsInsecure: failed to get image stream %s", is.Reference()))
@@ -243,7 +479,7 @@ func (is *imageStream) TagIsInsecure(ctx context.Context, tag string, dgst diges
 }
 
 func (is *imageStream) Exists(ctx context.Context) (bool, rerrors.Error) {
-	_, rErr := is.imageStreamGetter.get()
+	_, rErr := is.getStream(ctx)
 	if rErr != nil {
 		if rErr.Code() == ErrImageStreamGetterNotFoundCode {
 			return false, nil
@@ -319,7 +555,7 @@ func imageBlobReferencesHasBlob(info imageapiv1.ImageBlobReferences, dgst digest
 // instead. The repository is assumed to have the blob if its manifests use the
 // blob.
 func (is *imageStream) RemoteRepositoriesForBlob(ctx context.Context, dgst digest.Digest) ([]RemoteRepository, []ImageStreamReference, rerrors.Error) {
-	stream, err := is.imageStreamGetter.get()
+	stream, err := is.getStream(ctx)
 	if err != nil {
 		return nil, nil, convertImageStreamGetterError(err, fmt.Sprintf("RemoteRepositoriesForBlob: failed to get image stream %s", is.Reference()))
 	}
@@ -330,7 +566,7 @@ func (is *imageStream) RemoteRepositoriesForBlob(ctx context.Context, dgst diges
 		return nil, nil, nil
 	}
 
-	layers, err := is.imageStreamGetter.layers()
+	layers, err := is.getLayers(ctx)
 	if err != nil {
 		return nil, nil, convertImageStreamGetterError(err, fmt.Sprintf("RemoteRepositoriesForBlob: failed to get image stream layers %s", is.Reference()))
 	}
@@ -362,21 +598,60 @@ func (is *imageStream) RemoteRepositoriesForBlob(ctx context.Context, dgst diges
 // imported into the image stream and may have the manifest dgst. For the
 // repositories that are hosted by the local registry, image stream references
 // will be returned instead.
+//
+// If dgst identifies a manifest list (Docker manifest list or OCI image
+// index), the search also covers the platform-specific manifests referenced
+// by that index. The expansion only goes from a list to its children: a
+// dgst that is itself a child manifest is searched for on its own, without
+// looking up the list that references it.
 func (is *imageStream) RemoteRepositoriesForManifest(ctx context.Context, dgst digest.Digest) ([]RemoteRepository, []ImageStreamReference, rerrors.Error) {
-	stream, err := is.imageStreamGetter.get()
+	stream, err := is.getStream(ctx)
 	if err != nil {
 		return nil, nil, convertImageStreamGetterError(err, fmt.Sprintf("RemoteRepositoriesForManifest: failed to get image stream %s", is.Reference()))
 	}
 
-	repos, isrefs := remoteRepositoriesForImages(ctx, stream, []string{dgst.String()})
+	if !imageStreamHasExternalReferences(ctx, stream) {
+		// Same fast path as RemoteRepositoriesForBlob: with nothing
+		// imported from elsewhere there's no remote repository to find,
+		// so skip the ImageStreamLayers fetch manifestListChildren would
+		// otherwise make on every single-arch pull.
+		return nil, nil, nil
+	}
+
+	images := manifestListChildren(ctx, is, dgst)
+
+	repos, isrefs := remoteRepositoriesForImages(ctx, stream, images)
 
 	dcontext.GetLogger(ctx).Debugf("RemoteRepositoriesForManifest: repositories from imagestream %s for manifest %s: repos=%+v isrefs=%+v", is.Reference(), dgst, repos, isrefs)
 
 	return repos, isrefs, nil
 }
 
+// manifestListChildren returns dgst together with the digests of any
+// platform-specific manifests it references, according to the cached
+// ImageStreamLayers for is. If dgst is not known to be a manifest list, or
+// the layers cache is unavailable, only dgst itself is returned.
+func manifestListChildren(ctx context.Context, is *imageStream, dgst digest.Digest) []string {
+	images := []string{dgst.String()}
+
+	layers, err := is.getLayers(ctx)
+	if err != nil {
+		dcontext.GetLogger(ctx).Debugf("manifestListChildren: failed to get image stream layers %s: %v", is.Reference(), err)
+		return images
+	}
+
+	info, ok := layers.Images[dgst.String()]
+	if !ok || len(info.Manifests) == 0 {
+		return images
+	}
+
+	dcontext.GetLogger(ctx).Debugf("manifestListChildren: %s is a manifest list in %s, including child manifests %v", dgst, is.Reference(), info.Manifests)
+
+	return append(images, info.Manifests...)
+}
+
 func (is *imageStream) Tags(ctx context.Context) (map[string]digest.Digest, rerrors.Error) {
-	stream, err := is.imageStreamGetter.get()
+	stream, err := is.getStream(ctx)
 	if err != nil {
 		return nil, convertImageStreamGetterError(err, fmt.Sprintf("Tags: failed to get image stream %s", is.Reference()))
 	}
@@ -402,7 +677,17 @@ func (is *imageStream) Tags(ctx context.Context) (map[string]digest.Digest, rerr
 	return m, nil
 }
 
+func (is *imageStream) SetBlobStatter(statter distribution.BlobStatter) {
+	is.blobStatter = statter
+}
+
+func (is *imageStream) SetSharedCache(cache ProjectObjectListStore) {
+	is.sharedCache = cache
+}
+
 func (is *imageStream) CreateImageStreamMapping(ctx context.Context, userClient client.Interface, tag string, image *imageapiv1.Image) rerrors.Error {
+	backfillLayerSizes(ctx, is.blobStatter, image)
+
 	ism := imageapiv1.ImageStreamMapping{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: is.namespace,
@@ -505,7 +790,19 @@ func (is *imageStream) CreateImageStreamMapping(ctx context.Context, userClient
 	)
 }
 
-// GetLimitRangeList returns list of limit ranges for repo.
+// limitRangeCacheTTL and limitRangeNegativeCacheTTL bound how long
+// GetLimitRangeList trusts a cached result. Negative results (no LimitRange
+// in the namespace) get a shorter ttl since a namespace that starts setting
+// quota should take effect reasonably quickly.
+const (
+	limitRangeCacheTTL         = 1 * time.Minute
+	limitRangeNegativeCacheTTL = 10 * time.Second
+)
+
+// GetLimitRangeList returns list of limit ranges for repo. A cache miss is
+// coalesced through is.calls so that a burst of concurrent callers racing to
+// populate an empty cache only sends a single LimitRanges list request, not
+// one per caller.
 func (is *imageStream) GetLimitRangeList(ctx context.Context, cache ProjectObjectListStore) (*corev1.LimitRangeList, rerrors.Error) {
 	if cache != nil {
 		obj, exists, _ := cache.Get(is.namespace)
@@ -514,23 +811,34 @@ func (is *imageStream) GetLimitRangeList(ctx context.Context, cache ProjectObjec
 		}
 	}
 
-	dcontext.GetLogger(ctx).Debugf("listing limit ranges in namespace %s", is.namespace)
-
-	lrs, err := is.registryOSClient.LimitRanges(is.namespace).List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return nil, rerrors.NewError(
-			ErrImageStreamUnknownErrorCode,
-			fmt.Sprintf("GetLimitRangeList: failed to list limitranges for %s", is.Reference()),
-			err,
-		)
-	}
+	v, rErr := is.calls.do(ctx, "limitranges", func(ctx context.Context) (interface{}, rerrors.Error) {
+		dcontext.GetLogger(ctx).Debugf("listing limit ranges in namespace %s", is.namespace)
 
-	if cache != nil {
-		err = cache.Add(is.namespace, lrs)
+		lrs, err := is.registryOSClient.LimitRanges(is.namespace).List(ctx, metav1.ListOptions{})
 		if err != nil {
-			dcontext.GetLogger(ctx).Errorf("GetLimitRangeList: failed to cache limit range list: %v", err)
+			return nil, rerrors.NewError(
+				ErrImageStreamUnknownErrorCode,
+				fmt.Sprintf("GetLimitRangeList: failed to list limitranges for %s", is.Reference()),
+				err,
+			)
 		}
+
+		if cache != nil {
+			ttl := limitRangeCacheTTL
+			if len(lrs.Items) == 0 {
+				ttl = limitRangeNegativeCacheTTL
+			}
+			if err := cache.Add(is.namespace, lrs, ttl); err != nil {
+				dcontext.GetLogger(ctx).Errorf("GetLimitRangeList: failed to cache limit range list: %v", err)
+			}
+		}
+
+		return lrs, nil
+	})
+	if rErr != nil {
+		return nil, rErr
 	}
+	lrs := v.(*corev1.LimitRangeList)
 
 	return lrs, nil
 }
@@ -0,0 +1,69 @@
+package imagestream
+
+import (
+	"context"
+
+	"github.com/docker/distribution"
+	dcontext "github.com/docker/distribution/context"
+	"github.com/opencontainers/go-digest"
+
+	imageapiv1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/image-registry/pkg/origin-common/image/apis/image/docker10"
+)
+
+// backfillLayerSizes fills in LayerSize for any of image's DockerImageLayers
+// that are zero (common with schema1 manifests and some newer Docker
+// clients) by stat-ing the corresponding blob through statter, and
+// recomputes DockerImageMetadata.Size as the sum of the unique layer sizes.
+// Layer sizes are only filled where they are zero, and Size is only
+// recomputed when at least one layer was filled, so repeated calls are
+// idempotent. A
+// blob that can't be stat'd (for example a foreign/URL-only layer) is left
+// as-is and merely logged, since that isn't a mapping failure.
+func backfillLayerSizes(ctx context.Context, statter distribution.BlobStatter, image *imageapiv1.Image) {
+	if statter == nil || image == nil {
+		return
+	}
+
+	sizeByLayer := make(map[string]int64, len(image.DockerImageLayers))
+	var changed bool
+
+	for i := range image.DockerImageLayers {
+		layer := &image.DockerImageLayers[i]
+
+		if layer.LayerSize == 0 {
+			dgst, err := digest.Parse(layer.Name)
+			if err != nil {
+				dcontext.GetLogger(ctx).Errorf("backfillLayerSizes: image %s has layer with malformed digest %q: %v", image.Name, layer.Name, err)
+				continue
+			}
+
+			desc, err := statter.Stat(ctx, dgst)
+			if err != nil {
+				dcontext.GetLogger(ctx).Debugf("backfillLayerSizes: image %s: unable to stat layer %s, leaving its size as 0: %v", image.Name, dgst, err)
+				continue
+			}
+
+			layer.LayerSize = desc.Size
+			changed = true
+		}
+
+		sizeByLayer[layer.Name] = layer.LayerSize
+	}
+
+	if !changed {
+		return
+	}
+
+	meta, ok := image.DockerImageMetadata.Object.(*docker10.DockerImage)
+	if !ok {
+		return
+	}
+
+	var total int64
+	for _, size := range sizeByLayer {
+		total += size
+	}
+	meta.Size = total
+}
@@ -0,0 +1,126 @@
+package imagestream
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/docker/distribution"
+	"github.com/opencontainers/go-digest"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	imageapiv1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/image-registry/pkg/origin-common/image/apis/image/docker10"
+)
+
+type fakeBlobStatter struct {
+	sizes map[digest.Digest]int64
+}
+
+func (s fakeBlobStatter) Stat(ctx context.Context, dgst digest.Digest) (distribution.Descriptor, error) {
+	size, ok := s.sizes[dgst]
+	if !ok {
+		return distribution.Descriptor{}, fmt.Errorf("blob %s not found", dgst)
+	}
+	return distribution.Descriptor{Digest: dgst, Size: size}, nil
+}
+
+func newTestImage(layers ...imageapiv1.ImageLayer) *imageapiv1.Image {
+	return &imageapiv1.Image{
+		DockerImageLayers:   layers,
+		DockerImageMetadata: runtime.RawExtension{Object: &docker10.DockerImage{}},
+	}
+}
+
+func TestBackfillLayerSizesFillsZeroSizedLayers(t *testing.T) {
+	statter := fakeBlobStatter{sizes: map[digest.Digest]int64{
+		"sha256:aaa": 100,
+		"sha256:bbb": 200,
+	}}
+
+	image := newTestImage(
+		imageapiv1.ImageLayer{Name: "sha256:aaa", LayerSize: 0},
+		imageapiv1.ImageLayer{Name: "sha256:bbb", LayerSize: 200},
+	)
+
+	backfillLayerSizes(context.Background(), statter, image)
+
+	if got := image.DockerImageLayers[0].LayerSize; got != 100 {
+		t.Errorf("layer 0 size = %d, want 100", got)
+	}
+	if got := image.DockerImageLayers[1].LayerSize; got != 200 {
+		t.Errorf("layer 1 size = %d, want 200 (unchanged)", got)
+	}
+
+	meta := image.DockerImageMetadata.Object.(*docker10.DockerImage)
+	if meta.Size != 300 {
+		t.Errorf("total size = %d, want 300", meta.Size)
+	}
+}
+
+func TestBackfillLayerSizesSumsUniqueLayersOnly(t *testing.T) {
+	statter := fakeBlobStatter{sizes: map[digest.Digest]int64{"sha256:aaa": 100}}
+
+	// The same layer digest repeated (a common base-layer reuse pattern)
+	// must only be counted once toward the total.
+	image := newTestImage(
+		imageapiv1.ImageLayer{Name: "sha256:aaa", LayerSize: 0},
+		imageapiv1.ImageLayer{Name: "sha256:aaa", LayerSize: 0},
+	)
+
+	backfillLayerSizes(context.Background(), statter, image)
+
+	meta := image.DockerImageMetadata.Object.(*docker10.DockerImage)
+	if meta.Size != 100 {
+		t.Errorf("total size = %d, want 100 (deduplicated)", meta.Size)
+	}
+}
+
+func TestBackfillLayerSizesIsIdempotent(t *testing.T) {
+	statter := fakeBlobStatter{sizes: map[digest.Digest]int64{"sha256:aaa": 100}}
+	image := newTestImage(imageapiv1.ImageLayer{Name: "sha256:aaa", LayerSize: 0})
+
+	backfillLayerSizes(context.Background(), statter, image)
+	backfillLayerSizes(context.Background(), statter, image)
+
+	if got := image.DockerImageLayers[0].LayerSize; got != 100 {
+		t.Errorf("layer size after second call = %d, want 100", got)
+	}
+	meta := image.DockerImageMetadata.Object.(*docker10.DockerImage)
+	if meta.Size != 100 {
+		t.Errorf("total size after second call = %d, want 100", meta.Size)
+	}
+}
+
+func TestBackfillLayerSizesLeavesUnstattableForeignLayerAsIs(t *testing.T) {
+	// A foreign/URL-only layer's blob isn't stored in this registry, so
+	// Stat fails for it; it should be left at 0 rather than erroring out
+	// the whole mapping, while sibling layers still get backfilled.
+	statter := fakeBlobStatter{sizes: map[digest.Digest]int64{"sha256:bbb": 50}}
+
+	image := newTestImage(
+		imageapiv1.ImageLayer{Name: "sha256:aaa", LayerSize: 0},
+		imageapiv1.ImageLayer{Name: "sha256:bbb", LayerSize: 0},
+	)
+
+	backfillLayerSizes(context.Background(), statter, image)
+
+	if got := image.DockerImageLayers[0].LayerSize; got != 0 {
+		t.Errorf("foreign layer size = %d, want 0 (left as-is)", got)
+	}
+	if got := image.DockerImageLayers[1].LayerSize; got != 50 {
+		t.Errorf("stattable layer size = %d, want 50", got)
+	}
+
+	meta := image.DockerImageMetadata.Object.(*docker10.DockerImage)
+	if meta.Size != 50 {
+		t.Errorf("total size = %d, want 50", meta.Size)
+	}
+}
+
+func TestBackfillLayerSizesNilStatterOrImageIsNoop(t *testing.T) {
+	backfillLayerSizes(context.Background(), nil, newTestImage(imageapiv1.ImageLayer{Name: "sha256:aaa"}))
+	backfillLayerSizes(context.Background(), fakeBlobStatter{}, nil)
+}
@@ -0,0 +1,129 @@
+package imagestream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var (
+	cacheHitsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "imageregistry",
+		Subsystem: "imagestream_cache",
+		Name:      "hits_total",
+		Help:      "Number of ProjectObjectListStore lookups that found a live entry.",
+	}, []string{"store"})
+
+	cacheMissesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "imageregistry",
+		Subsystem: "imagestream_cache",
+		Name:      "misses_total",
+		Help:      "Number of ProjectObjectListStore lookups that found no entry.",
+	}, []string{"store"})
+
+	cacheExpiriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "imageregistry",
+		Subsystem: "imagestream_cache",
+		Name:      "expiries_total",
+		Help:      "Number of ProjectObjectListStore entries removed because their ttl elapsed.",
+	}, []string{"store"})
+)
+
+func init() {
+	prometheus.MustRegister(cacheHitsTotal, cacheMissesTotal, cacheExpiriesTotal)
+}
+
+// EvictionFunc is called, outside of any lock, whenever an entry is evicted
+// from an objectListStore -- because it expired, was invalidated, or was
+// displaced by a newer Add for the same key.
+type EvictionFunc func(key string, obj runtime.Object)
+
+// ObjectListStoreOptions configures a new in-memory ProjectObjectListStore.
+type ObjectListStoreOptions struct {
+	// Name identifies this store in its Prometheus metrics, e.g.
+	// "limitranges", "secrets" or "imagestreams".
+	Name string
+	// OnEvict, if set, is called whenever an entry is evicted.
+	OnEvict EvictionFunc
+}
+
+type objectListEntry struct {
+	obj     runtime.Object
+	expires time.Time
+}
+
+// objectListStore is the default in-memory ProjectObjectListStore. Each
+// entry carries its own ttl, so callers can cache a negative result (e.g.
+// "no LimitRange in this namespace") for a shorter window than a populated
+// one. Hits, misses and expiries are reported as Prometheus counters so
+// operators can tune TTLs.
+type objectListStore struct {
+	name    string
+	onEvict EvictionFunc
+
+	mu      sync.Mutex
+	entries map[string]objectListEntry
+}
+
+// NewObjectListStore returns a new, empty ProjectObjectListStore.
+func NewObjectListStore(opts ObjectListStoreOptions) ProjectObjectListStore {
+	return &objectListStore{
+		name:    opts.Name,
+		onEvict: opts.OnEvict,
+		entries: make(map[string]objectListEntry),
+	}
+}
+
+func (s *objectListStore) Add(namespace string, obj runtime.Object, ttl time.Duration) error {
+	s.mu.Lock()
+	old, hadOld := s.entries[namespace]
+	s.entries[namespace] = objectListEntry{obj: obj, expires: time.Now().Add(ttl)}
+	s.mu.Unlock()
+
+	if hadOld && s.onEvict != nil {
+		s.onEvict(namespace, old.obj)
+	}
+
+	return nil
+}
+
+func (s *objectListStore) Get(namespace string) (runtime.Object, bool, error) {
+	s.mu.Lock()
+	entry, ok := s.entries[namespace]
+	expired := ok && time.Now().After(entry.expires)
+	if expired {
+		delete(s.entries, namespace)
+	}
+	s.mu.Unlock()
+
+	switch {
+	case !ok:
+		cacheMissesTotal.WithLabelValues(s.name).Inc()
+		return nil, false, nil
+	case expired:
+		cacheExpiriesTotal.WithLabelValues(s.name).Inc()
+		if s.onEvict != nil {
+			s.onEvict(namespace, entry.obj)
+		}
+		return nil, false, nil
+	}
+
+	cacheHitsTotal.WithLabelValues(s.name).Inc()
+	return entry.obj, true, nil
+}
+
+func (s *objectListStore) Invalidate(namespace string) {
+	s.mu.Lock()
+	entry, ok := s.entries[namespace]
+	if ok {
+		delete(s.entries, namespace)
+	}
+	s.mu.Unlock()
+
+	if ok && s.onEvict != nil {
+		s.onEvict(namespace, entry.obj)
+	}
+}
@@ -0,0 +1,194 @@
+package imagestream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// fakeObject is a minimal runtime.Object stand-in for exercising the store
+// without pulling in a real API type.
+type fakeObject struct {
+	metav1.TypeMeta
+	id string
+}
+
+func (o *fakeObject) DeepCopyObject() runtime.Object {
+	cp := *o
+	return &cp
+}
+
+func TestObjectListStoreAddAndGet(t *testing.T) {
+	s := NewObjectListStore(ObjectListStoreOptions{Name: "test-add-get"})
+
+	if _, exists, err := s.Get("ns"); err != nil || exists {
+		t.Fatalf("Get on empty store: exists=%v err=%v, want exists=false err=nil", exists, err)
+	}
+
+	want := &fakeObject{id: "a"}
+	if err := s.Add("ns", want, time.Minute); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	got, exists, err := s.Get("ns")
+	if err != nil || !exists {
+		t.Fatalf("Get after Add: exists=%v err=%v, want exists=true err=nil", exists, err)
+	}
+	if got != want {
+		t.Fatalf("Get after Add = %v, want %v", got, want)
+	}
+}
+
+func TestObjectListStoreExpiresAfterTTL(t *testing.T) {
+	s := NewObjectListStore(ObjectListStoreOptions{Name: "test-expiry"})
+
+	if err := s.Add("ns", &fakeObject{id: "a"}, 10*time.Millisecond); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if _, exists, err := s.Get("ns"); err != nil || exists {
+		t.Fatalf("Get after ttl elapsed: exists=%v err=%v, want exists=false err=nil", exists, err)
+	}
+}
+
+func TestObjectListStoreNegativeResultGetsItsOwnShorterTTL(t *testing.T) {
+	s := NewObjectListStore(ObjectListStoreOptions{Name: "test-negative-ttl"})
+
+	if err := s.Add("populated", &fakeObject{id: "a"}, time.Hour); err != nil {
+		t.Fatalf("Add populated: %v", err)
+	}
+	if err := s.Add("empty", &fakeObject{id: ""}, 10*time.Millisecond); err != nil {
+		t.Fatalf("Add empty: %v", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if _, exists, _ := s.Get("populated"); !exists {
+		t.Error("expected the long-ttl entry to still be cached")
+	}
+	if _, exists, _ := s.Get("empty"); exists {
+		t.Error("expected the short-ttl (negative-result) entry to have expired")
+	}
+}
+
+func TestObjectListStoreAddDisplacesAndEvictsPreviousEntry(t *testing.T) {
+	var evicted []string
+	s := NewObjectListStore(ObjectListStoreOptions{
+		Name: "test-displace",
+		OnEvict: func(namespace string, obj runtime.Object) {
+			evicted = append(evicted, namespace+":"+obj.(*fakeObject).id)
+		},
+	})
+
+	if err := s.Add("ns", &fakeObject{id: "old"}, time.Minute); err != nil {
+		t.Fatalf("Add old: %v", err)
+	}
+	if err := s.Add("ns", &fakeObject{id: "new"}, time.Minute); err != nil {
+		t.Fatalf("Add new: %v", err)
+	}
+
+	if len(evicted) != 1 || evicted[0] != "ns:old" {
+		t.Fatalf("evicted = %v, want [\"ns:old\"]", evicted)
+	}
+
+	got, _, _ := s.Get("ns")
+	if got.(*fakeObject).id != "new" {
+		t.Fatalf("Get after displacement = %v, want new", got)
+	}
+}
+
+func TestObjectListStoreInvalidateFiresEvictionCallback(t *testing.T) {
+	var evicted []string
+	s := NewObjectListStore(ObjectListStoreOptions{
+		Name: "test-invalidate",
+		OnEvict: func(namespace string, obj runtime.Object) {
+			evicted = append(evicted, namespace)
+		},
+	})
+
+	// Invalidating a namespace with nothing cached must not fire the callback.
+	s.Invalidate("ns")
+	if len(evicted) != 0 {
+		t.Fatalf("evicted = %v after invalidating an empty entry, want none", evicted)
+	}
+
+	if err := s.Add("ns", &fakeObject{id: "a"}, time.Minute); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	s.Invalidate("ns")
+	if len(evicted) != 1 || evicted[0] != "ns" {
+		t.Fatalf("evicted = %v, want [\"ns\"]", evicted)
+	}
+
+	if _, exists, _ := s.Get("ns"); exists {
+		t.Error("expected Get to miss after Invalidate")
+	}
+}
+
+func TestObjectListStoreEvictionCallbackRunsOutsideTheLock(t *testing.T) {
+	// A callback that reenters the store (as GetSecrets/GetLimitRangeList's
+	// callers might, e.g. to re-populate after a 401) would deadlock if
+	// onEvict ran while the store's own lock was still held.
+	var s ProjectObjectListStore
+	done := make(chan struct{})
+	s = NewObjectListStore(ObjectListStoreOptions{
+		Name: "test-no-lock-reentry",
+		OnEvict: func(namespace string, obj runtime.Object) {
+			s.Add("other", &fakeObject{id: "reentrant"}, time.Minute)
+			close(done)
+		},
+	})
+
+	if err := s.Add("ns", &fakeObject{id: "a"}, 10*time.Millisecond); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	if _, _, err := s.Get("ns"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("eviction callback never completed -- OnEvict likely deadlocked reentering the store")
+	}
+
+	if _, exists, _ := s.Get("other"); !exists {
+		t.Error("expected the reentrant Add from inside OnEvict to have taken effect")
+	}
+}
+
+func TestObjectListStoreReportsPrometheusCounters(t *testing.T) {
+	s := NewObjectListStore(ObjectListStoreOptions{Name: "test-metrics"})
+
+	before := testutil.ToFloat64(cacheMissesTotal.WithLabelValues("test-metrics"))
+	s.Get("ns")
+	if after := testutil.ToFloat64(cacheMissesTotal.WithLabelValues("test-metrics")); after != before+1 {
+		t.Errorf("misses_total = %v, want %v", after, before+1)
+	}
+
+	s.Add("ns", &fakeObject{id: "a"}, time.Minute)
+
+	before = testutil.ToFloat64(cacheHitsTotal.WithLabelValues("test-metrics"))
+	s.Get("ns")
+	if after := testutil.ToFloat64(cacheHitsTotal.WithLabelValues("test-metrics")); after != before+1 {
+		t.Errorf("hits_total = %v, want %v", after, before+1)
+	}
+
+	s.Add("expiring", &fakeObject{id: "b"}, 10*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+
+	before = testutil.ToFloat64(cacheExpiriesTotal.WithLabelValues("test-metrics"))
+	s.Get("expiring")
+	if after := testutil.ToFloat64(cacheExpiriesTotal.WithLabelValues("test-metrics")); after != before+1 {
+		t.Errorf("expiries_total = %v, want %v", after, before+1)
+	}
+}
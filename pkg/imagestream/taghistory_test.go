@@ -0,0 +1,67 @@
+package imagestream
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	imageapiv1 "github.com/openshift/api/image/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func tagEventAt(generation int64, created time.Time) *imageapiv1.TagEvent {
+	return &imageapiv1.TagEvent{
+		Generation: generation,
+		Created:    metav1.NewTime(created),
+	}
+}
+
+func TestNewerTagEventPrefersHigherGeneration(t *testing.T) {
+	now := time.Now()
+	older := tagEventAt(1, now)
+	newer := tagEventAt(2, now)
+
+	if !newerTagEvent(newer, older) {
+		t.Error("expected higher generation to be newer regardless of Created")
+	}
+	if newerTagEvent(older, newer) {
+		t.Error("expected lower generation to not be newer")
+	}
+}
+
+func TestNewerTagEventBreaksGenerationTieByCreated(t *testing.T) {
+	now := time.Now()
+	earlier := tagEventAt(5, now)
+	later := tagEventAt(5, now.Add(time.Minute))
+
+	if !newerTagEvent(later, earlier) {
+		t.Error("expected the later-created event to win a generation tie")
+	}
+	if newerTagEvent(earlier, later) {
+		t.Error("expected the earlier-created event to lose a generation tie")
+	}
+}
+
+func TestNewerTagEventEqualEventsAreNotNewer(t *testing.T) {
+	now := time.Now()
+	a := tagEventAt(3, now)
+	b := tagEventAt(3, now)
+
+	if newerTagEvent(a, b) {
+		t.Error("expected two events with identical generation and timestamp to not be newer than one another")
+	}
+}
+
+func TestByGenerationSortsNewestFirst(t *testing.T) {
+	now := time.Now()
+	oldest := tagEventAt(1, now)
+	middle := tagEventAt(2, now)
+	tiedButLater := tagEventAt(2, now.Add(time.Second))
+
+	events := ByGeneration{oldest, middle, tiedButLater}
+	sort.Sort(events)
+
+	if events[0] != tiedButLater || events[1] != middle || events[2] != oldest {
+		t.Fatalf("sorted order = %v, want [tiedButLater, middle, oldest]", events)
+	}
+}
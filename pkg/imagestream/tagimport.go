@@ -0,0 +1,193 @@
+package imagestream
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	dcontext "github.com/docker/distribution/context"
+	"github.com/opencontainers/go-digest"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	imageapiv1 "github.com/openshift/api/image/v1"
+
+	rerrors "github.com/openshift/image-registry/pkg/errors"
+)
+
+// defaultTagImportTimeout bounds how long a lazy tag import is allowed to
+// block a pull before giving up, so a slow or unreachable upstream registry
+// doesn't stall the client indefinitely.
+const defaultTagImportTimeout = 10 * time.Second
+
+// tagImportDebouncer coalesces concurrent lazy imports of the same tag, so a
+// burst of pulls for a tag that hasn't been imported yet results in a single
+// ImageStreamImports request instead of one per puller.
+type tagImportDebouncer struct {
+	mu      sync.Mutex
+	pending map[string]*tagImportCall
+}
+
+type tagImportCall struct {
+	done chan struct{}
+	err  rerrors.Error
+}
+
+func newTagImportDebouncer() *tagImportDebouncer {
+	return &tagImportDebouncer{
+		pending: make(map[string]*tagImportCall),
+	}
+}
+
+// do runs fn for tag, coalescing concurrent calls for the same tag into a
+// single invocation. Callers that arrive while an import is already running
+// wait for it to finish and share its result.
+func (d *tagImportDebouncer) do(tag string, fn func() rerrors.Error) rerrors.Error {
+	d.mu.Lock()
+	if call, ok := d.pending[tag]; ok {
+		d.mu.Unlock()
+		<-call.done
+		return call.err
+	}
+
+	call := &tagImportCall{done: make(chan struct{})}
+	d.pending[tag] = call
+	d.mu.Unlock()
+
+	call.err = fn()
+	close(call.done)
+
+	d.mu.Lock()
+	delete(d.pending, tag)
+	d.mu.Unlock()
+
+	return call.err
+}
+
+// ResolveTag resolves tag to the digest it currently points at. If the tag
+// is only known through a Spec.Tags DockerImage reference and has no
+// Status.Tags entry yet, it is imported synchronously first, mirroring what
+// the background importer (pkg/image/importer) would eventually do, and the
+// image stream cache is refreshed before the lookup is retried.
+func (is *imageStream) ResolveTag(ctx context.Context, tag string) (digest.Digest, rerrors.Error) {
+	if rErr := is.ensureTagImported(ctx, tag); rErr != nil {
+		return "", rErr
+	}
+
+	tags, rErr := is.Tags(ctx)
+	if rErr != nil {
+		return "", rErr
+	}
+
+	dgst, ok := tags[tag]
+	if !ok {
+		return "", rerrors.NewError(
+			ErrImageStreamImageNotFoundCode,
+			fmt.Sprintf("ResolveTag: tag %s not found in image stream %s", tag, is.Reference()),
+			nil,
+		)
+	}
+
+	return dgst, nil
+}
+
+// ensureTagImported is a no-op unless tag exists only in Spec.Tags as a
+// DockerImage reference and has not been imported yet, in which case it
+// triggers a synchronous import.
+func (is *imageStream) ensureTagImported(ctx context.Context, tag string) rerrors.Error {
+	stream, err := is.getStream(ctx)
+	if err != nil {
+		return convertImageStreamGetterError(err, fmt.Sprintf("ensureTagImported: failed to get image stream %s", is.Reference()))
+	}
+
+	if tagHasStatus(stream, tag) {
+		return nil
+	}
+
+	from, ok := specTagDockerImageReference(stream, tag)
+	if !ok {
+		return nil
+	}
+
+	return is.importDebounce.do(tag, func() rerrors.Error {
+		return is.importTag(ctx, tag, from)
+	})
+}
+
+func tagHasStatus(stream *imageapiv1.ImageStream, tag string) bool {
+	for _, t := range stream.Status.Tags {
+		if t.Tag == tag && len(t.Items) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func specTagDockerImageReference(stream *imageapiv1.ImageStream, tag string) (string, bool) {
+	for _, t := range stream.Spec.Tags {
+		if t.Name == tag && t.From != nil && t.From.Kind == "DockerImage" {
+			return t.From.Name, true
+		}
+	}
+	return "", false
+}
+
+// importTag runs a synchronous ImageStreamImports request for tag against
+// the master and invalidates the cached image stream so that the next get()
+// observes the freshly imported Status.Tags entry.
+func (is *imageStream) importTag(ctx context.Context, tag, from string) rerrors.Error {
+	ctx, cancel := context.WithTimeout(ctx, defaultTagImportTimeout)
+	defer cancel()
+
+	insecure, rErr := is.TagIsInsecure(ctx, tag, "")
+	if rErr != nil {
+		return rErr
+	}
+
+	isi := &imageapiv1.ImageStreamImport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: is.namespace,
+			Name:      is.name,
+		},
+		Spec: imageapiv1.ImageStreamImportSpec{
+			Import: true,
+			Images: []imageapiv1.ImageImportSpec{
+				{
+					From:         corev1.ObjectReference{Kind: "DockerImage", Name: from},
+					To:           &corev1.LocalObjectReference{Name: tag},
+					ImportPolicy: imageapiv1.TagImportPolicy{Insecure: insecure},
+				},
+			},
+		},
+	}
+
+	dcontext.GetLogger(ctx).Debugf("importTag: importing tag %s (%s) into %s", tag, from, is.Reference())
+
+	result, err := is.registryOSClient.ImageStreamImports(is.namespace).Create(ctx, isi, metav1.CreateOptions{})
+	if err != nil {
+		return rerrors.NewError(
+			ErrImageStreamUnknownErrorCode,
+			fmt.Sprintf("importTag: error importing tag %s into %s", tag, is.Reference()),
+			err,
+		)
+	}
+
+	for _, status := range result.Status.Images {
+		if status.Status.Status != metav1.StatusSuccess {
+			return rerrors.NewError(
+				ErrImageStreamImageNotFoundCode,
+				fmt.Sprintf("importTag: failed to import tag %s into %s: %s", tag, is.Reference(), status.Status.Message),
+				nil,
+			)
+		}
+	}
+
+	is.imageStreamGetter.invalidate()
+	if is.sharedCache != nil {
+		is.sharedCache.Invalidate(is.Reference())
+	}
+
+	return nil
+}